@@ -0,0 +1,35 @@
+// Command dagu-agent runs on a remote host and executes steps dispatched
+// to it by a dagu scheduler whose DAG has a step with a matching
+// `runsOn:` selector. See internal/agent and internal/agentproto.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/yohamta/dagu/internal/agent"
+)
+
+func main() {
+	addr := flag.String("addr", ":8811", "address to listen on")
+	tlsCert := flag.String("tls-cert", "", "path to the TLS certificate to serve with (required)")
+	tlsKey := flag.String("tls-key", "", "path to the TLS private key to serve with (required)")
+	flag.Parse()
+
+	key := []byte(os.Getenv("DAGU_AGENT_TOKEN_KEY"))
+	if len(key) == 0 {
+		log.Fatal("DAGU_AGENT_TOKEN_KEY must be set to the shared key used to verify scheduler tokens")
+	}
+	// The scheduler forwards resolved secret values and a replayable
+	// bearer token to this server; serving plain HTTP would put both on
+	// the wire in cleartext, so require TLS rather than defaulting to it.
+	if *tlsCert == "" || *tlsKey == "" {
+		log.Fatal("-tls-cert and -tls-key must both be set; dagu-agent does not serve plain HTTP")
+	}
+
+	srv := agent.NewServer(key)
+	log.Printf("dagu-agent listening on %s", *addr)
+	log.Fatal(http.ListenAndServeTLS(*addr, *tlsCert, *tlsKey, srv.Handler()))
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yohamta/dagu/internal/scheduler"
+)
+
+// NodeMetricsLookup resolves a DAG run + node name to that node's
+// resource usage, as recorded by the scheduler's per-node metrics
+// sampler.
+type NodeMetricsLookup func(requestId, nodeName string) (scheduler.Metrics, bool)
+
+// HandleGetNodeMetrics exposes a node's sampled CPU/memory/IO usage so
+// the UI can show which step in a DAG run was the resource hog.
+func HandleGetNodeMetrics(lookup NodeMetricsLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.URL.Query().Get("requestId")
+		node := r.URL.Query().Get("node")
+		if requestId == "" || node == "" {
+			http.Error(w, "requestId and node query params are required", http.StatusBadRequest)
+			return
+		}
+
+		m, ok := lookup(requestId, node)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(m); err != nil {
+			encodeError(w, err)
+		}
+	}
+}
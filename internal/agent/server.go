@@ -0,0 +1,239 @@
+// Package agent implements the dagu-agent side of the protocol defined in
+// internal/agentproto: it receives a step from a scheduler's RemoteRuntime
+// and runs it locally, the same way the scheduler itself would via
+// scheduler.LocalRuntime, then reports status/output back.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/yohamta/dagu/internal/agentproto"
+)
+
+// stepRetention is how long a finished step's runningStep (including its
+// captured output buffer) stays in s.steps after it exits, so a late
+// Wait/output call still sees it, before it's swept - without this, a
+// long-lived dagu-agent process accumulates one runningStep per step it
+// has ever run, for its entire lifetime.
+const stepRetention = 5 * time.Minute
+
+// Server is an http.Handler exposing StartStep/StreamLogs/Signal/Wait/
+// FetchOutput for steps dispatched by a remote scheduler.
+type Server struct {
+	key []byte // verifies tokens minted by schedulers this agent trusts
+
+	mu    sync.Mutex
+	steps map[string]*runningStep
+	next  int
+}
+
+type runningStep struct {
+	scope  string // tok.Scope this step was started under; only that scope may act on it
+	cmd    *exec.Cmd
+	output bytes.Buffer
+	logs   chan agentproto.LogChunk
+	done   chan error
+}
+
+func NewServer(trustedKey []byte) *Server {
+	return &Server{key: trustedKey, steps: map[string]*runningStep{}}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /steps", s.handleStart)
+	mux.HandleFunc("GET /steps/{id}/logs", s.handleLogs)
+	mux.HandleFunc("POST /steps/{id}/signal", s.handleSignal)
+	mux.HandleFunc("GET /steps/{id}/wait", s.handleWait)
+	mux.HandleFunc("GET /steps/{id}/output", s.handleOutput)
+	return s.authenticated(mux)
+}
+
+type tokenCtxKey struct{}
+
+func (s *Server) authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tok agentproto.Token
+		if err := json.Unmarshal([]byte(trimBearer(r.Header.Get("Authorization"))), &tok); err != nil {
+			http.Error(w, "missing or malformed token", http.StatusUnauthorized)
+			return
+		}
+		if err := agentproto.Verify(tok, s.key); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), tokenCtxKey{}, tok)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func tokenFrom(r *http.Request) agentproto.Token {
+	tok, _ := r.Context().Value(tokenCtxKey{}).(agentproto.Token)
+	return tok
+}
+
+func trimBearer(h string) string {
+	const prefix = "Bearer "
+	if len(h) > len(prefix) && h[:len(prefix)] == prefix {
+		return h[len(prefix):]
+	}
+	return h
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	var spec agentproto.StepSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The token authenticated this request, but scope is per-step: a
+	// token minted for "step:a" must not be able to start a step it
+	// names "step:b" and then use that same token against the real a.
+	wantScope := "step:" + spec.Name
+	if tok := tokenFrom(r); tok.Scope != wantScope {
+		http.Error(w, fmt.Sprintf("token scope %q cannot start step %q", tok.Scope, spec.Name), http.StatusForbidden)
+		return
+	}
+
+	cmd := exec.CommandContext(context.Background(), spec.Cmd, spec.Args...)
+	cmd.Dir = spec.Dir
+	cmd.Env = spec.Env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
+
+	rs := &runningStep{scope: wantScope, logs: make(chan agentproto.LogChunk, 64), done: make(chan error, 1)}
+	cmd.Stdout = io.MultiWriter(&rs.output, streamWriter{rs, "stdout"})
+	cmd.Stderr = streamWriter{rs, "stderr"}
+	rs.cmd = cmd
+
+	s.mu.Lock()
+	s.next++
+	id := strconv.Itoa(s.next)
+	s.steps[id] = rs
+	s.mu.Unlock()
+
+	go func() {
+		err := cmd.Run()
+		close(rs.logs)
+		rs.done <- err
+		time.AfterFunc(stepRetention, func() {
+			s.mu.Lock()
+			delete(s.steps, id)
+			s.mu.Unlock()
+		})
+	}()
+
+	writeJSON(w, agentproto.StartStepResponse{StepID: id})
+}
+
+type streamWriter struct {
+	rs     *runningStep
+	stream string
+}
+
+func (sw streamWriter) Write(p []byte) (int, error) {
+	sw.rs.logs <- agentproto.LogChunk{Stream: sw.stream, Data: string(p)}
+	return len(p), nil
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	rs, ok := s.lookup(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	enc := json.NewEncoder(w)
+	for chunk := range rs.logs {
+		if err := enc.Encode(chunk); err != nil {
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+func (s *Server) handleSignal(w http.ResponseWriter, r *http.Request) {
+	rs, ok := s.lookup(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	var req agentproto.SignalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sig, ok := namedSignals[req.Signal]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown signal %q", req.Signal), http.StatusBadRequest)
+		return
+	}
+	if rs.cmd.Process != nil {
+		_ = syscall.Kill(-rs.cmd.Process.Pid, sig)
+	}
+}
+
+func (s *Server) handleWait(w http.ResponseWriter, r *http.Request) {
+	rs, ok := s.lookup(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	err := <-rs.done
+	rs.done <- err // allow a second Wait caller to observe the same result
+	resp := agentproto.WaitResponse{}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			resp.ExitCode = exitErr.ExitCode()
+		} else {
+			resp.Error = err.Error()
+		}
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleOutput(w http.ResponseWriter, r *http.Request) {
+	rs, ok := s.lookup(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(rs.output.Bytes())
+}
+
+// lookup finds the step named in the URL and requires the request's token
+// to carry the same scope the step was started under - otherwise a token
+// minted for one step could kill/wait/read-output on any other step this
+// agent happens to be running.
+func (s *Server) lookup(r *http.Request) (*runningStep, bool) {
+	s.mu.Lock()
+	rs, ok := s.steps[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok || rs.scope != tokenFrom(r).Scope {
+		return nil, false
+	}
+	return rs, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+var namedSignals = map[string]syscall.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGINT":  syscall.SIGINT,
+	"SIGHUP":  syscall.SIGHUP,
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yohamta/dagu/internal/agentproto"
+)
+
+func doRequest(t *testing.T, url, method string, tok agentproto.Token, body any) *http.Response {
+	t.Helper()
+	var reqBody *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqBody = bytes.NewReader(b)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tb, _ := json.Marshal(tok)
+	req.Header.Set("Authorization", "Bearer "+string(tb))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestServer_TokenScopeIsEnforcedAcrossSteps(t *testing.T) {
+	key := []byte("test-key")
+	srv := httptest.NewServer(NewServer(key).Handler())
+	defer srv.Close()
+
+	tokA := agentproto.IssueToken("scheduler", "step:a", time.Minute, key)
+	tokB := agentproto.IssueToken("scheduler", "step:b", time.Minute, key)
+
+	startResp := doRequest(t, srv.URL+"/steps", http.MethodPost, tokA, agentproto.StepSpec{
+		Name: "a", Cmd: "echo", Args: []string{"hi"},
+	})
+	defer startResp.Body.Close()
+	if startResp.StatusCode != http.StatusOK {
+		t.Fatalf("start step under matching scope: got status %d", startResp.StatusCode)
+	}
+	var started agentproto.StartStepResponse
+	if err := json.NewDecoder(startResp.Body).Decode(&started); err != nil {
+		t.Fatal(err)
+	}
+
+	waitResp := doRequest(t, srv.URL+"/steps/"+started.StepID+"/wait", http.MethodGet, tokB, nil)
+	defer waitResp.Body.Close()
+	if waitResp.StatusCode != http.StatusNotFound {
+		t.Fatalf("token scoped to %q reached step started under %q: got status %d, want %d",
+			tokB.Scope, tokA.Scope, waitResp.StatusCode, http.StatusNotFound)
+	}
+
+	okResp := doRequest(t, srv.URL+"/steps/"+started.StepID+"/wait", http.MethodGet, tokA, nil)
+	defer okResp.Body.Close()
+	if okResp.StatusCode != http.StatusOK {
+		t.Fatalf("token scoped to %q could not reach its own step: got status %d", tokA.Scope, okResp.StatusCode)
+	}
+}
+
+func TestServer_StartRejectsMismatchedScope(t *testing.T) {
+	key := []byte("test-key")
+	srv := httptest.NewServer(NewServer(key).Handler())
+	defer srv.Close()
+
+	tok := agentproto.IssueToken("scheduler", "step:a", time.Minute, key)
+	resp := doRequest(t, srv.URL+"/steps", http.MethodPost, tok, agentproto.StepSpec{
+		Name: "b", Cmd: "echo",
+	})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("token scoped to \"step:a\" started step \"b\": got status %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
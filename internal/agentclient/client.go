@@ -0,0 +1,144 @@
+// Package agentclient is the scheduler-side client for the dagu-agent
+// protocol defined in internal/agentproto. A RemoteRuntime uses it to
+// dispatch a Node's step to whichever agent matches the step's `runsOn:`
+// selector.
+package agentclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yohamta/dagu/internal/agentproto"
+)
+
+// Client talks to a single agent at addr, authenticating with token.
+type Client struct {
+	addr  string
+	token agentproto.Token
+	http  *http.Client
+}
+
+func New(addr string, token agentproto.Token) *Client {
+	return &Client{addr: addr, token: token, http: http.DefaultClient}
+}
+
+func (c *Client) StartStep(ctx context.Context, spec agentproto.StepSpec) (string, error) {
+	var resp agentproto.StartStepResponse
+	if err := c.do(ctx, http.MethodPost, "/steps", spec, &resp); err != nil {
+		return "", err
+	}
+	return resp.StepID, nil
+}
+
+// StreamLogs reads the agent's log stream for stepID until it closes
+// (step exited) or ctx is canceled, tagging each chunk onto stdout or
+// stderr as told by the agent.
+func (c *Client) StreamLogs(ctx context.Context, stepID string, stdout, stderr io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url("/steps/"+stepID+"/logs"), nil)
+	if err != nil {
+		return err
+	}
+	c.authorize(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("agent returned status %s streaming logs for %s", resp.Status, stepID)
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		var chunk agentproto.LogChunk
+		if err := dec.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		w := stdout
+		if chunk.Stream == "stderr" {
+			w = stderr
+		}
+		if _, err := io.WriteString(w, chunk.Data); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) Signal(ctx context.Context, stepID string, sig string) error {
+	return c.do(ctx, http.MethodPost, "/steps/"+stepID+"/signal", agentproto.SignalRequest{Signal: sig}, nil)
+}
+
+func (c *Client) Wait(ctx context.Context, stepID string) error {
+	var resp agentproto.WaitResponse
+	if err := c.do(ctx, http.MethodGet, "/steps/"+stepID+"/wait", nil, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("remote step failed: %s", resp.Error)
+	}
+	if resp.ExitCode != 0 {
+		return fmt.Errorf("remote step exited with code %d", resp.ExitCode)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path), reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.authorize(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("agent returned status %s for %s %s", resp.Status, method, path)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Secure reports whether addr does NOT carry an explicit http:// scheme -
+// i.e. false means traffic to this agent, including the bearer token and
+// any resolved secret values RemoteRuntime forwards, goes out in cleartext.
+// addr is treated as secure unless it opts into http:// explicitly;
+// anything else (no scheme, or an explicit https://) gets TLS.
+func (c *Client) Secure() bool {
+	return !strings.HasPrefix(c.addr, "http://")
+}
+
+func (c *Client) url(path string) string {
+	if strings.Contains(c.addr, "://") {
+		return c.addr + path
+	}
+	return "https://" + c.addr + path
+}
+
+func (c *Client) authorize(req *http.Request) {
+	b, _ := json.Marshal(c.token)
+	req.Header.Set("Authorization", "Bearer "+string(b))
+}
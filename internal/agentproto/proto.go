@@ -0,0 +1,51 @@
+// Package agentproto defines the wire types shared between the scheduler
+// and a dagu-agent process for running a Node's step on a remote host.
+// The protocol is plain JSON over HTTP rather than gRPC: it keeps the
+// agent binary a single static file with no codegen step, matching how
+// the rest of dagu avoids heavyweight dependencies where a small HTTP
+// API will do.
+package agentproto
+
+import "time"
+
+// StepSpec describes the command a RemoteRuntime wants an agent to run.
+type StepSpec struct {
+	Name string   `json:"name"`
+	Dir  string   `json:"dir"`
+	Cmd  string   `json:"cmd"`
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+}
+
+// StartStepResponse is returned by POST /steps.
+type StartStepResponse struct {
+	StepID string `json:"stepId"`
+}
+
+// LogChunk is one frame of a StreamLogs response, written as
+// newline-delimited JSON so a client can read it incrementally.
+type LogChunk struct {
+	Stream string `json:"stream"` // "stdout" or "stderr"
+	Data   string `json:"data"`
+}
+
+// WaitResponse is returned by GET /steps/{id}/wait once the step exits.
+type WaitResponse struct {
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SignalRequest is the body of POST /steps/{id}/signal.
+type SignalRequest struct {
+	Signal string `json:"signal"` // e.g. "SIGTERM", "SIGKILL"
+}
+
+// Token is a scoped, time-limited credential minted by the scheduler (or
+// a peer agent) that lets an agent call back into the issuing party's API
+// to fetch outputs or report status, without a long-lived shared secret.
+type Token struct {
+	Subject   string    `json:"sub"`   // identity the token acts as (the originating user)
+	Scope     string    `json:"scope"` // e.g. "step:run-123"
+	ExpiresAt time.Time `json:"exp"`
+	Signature string    `json:"sig"`
+}
@@ -0,0 +1,42 @@
+package agentproto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// IssueToken mints a Token scoped to scope, valid for ttl, signed with
+// key. The key is whatever credential the issuing scheduler/agent already
+// holds for the target peer (e.g. a per-cluster shared key configured
+// alongside `runsOn:` selectors) - it is never the long-lived credential
+// itself, only something that can stamp short-lived scoped tokens.
+func IssueToken(subject, scope string, ttl time.Duration, key []byte) Token {
+	t := Token{
+		Subject:   subject,
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	t.Signature = sign(t, key)
+	return t
+}
+
+// Verify checks a Token's signature and expiry against key.
+func Verify(t Token, key []byte) error {
+	if time.Now().After(t.ExpiresAt) {
+		return fmt.Errorf("token for scope %q expired at %s", t.Scope, t.ExpiresAt)
+	}
+	want := sign(Token{Subject: t.Subject, Scope: t.Scope, ExpiresAt: t.ExpiresAt}, key)
+	if !hmac.Equal([]byte(want), []byte(t.Signature)) {
+		return fmt.Errorf("token for scope %q has an invalid signature", t.Scope)
+	}
+	return nil
+}
+
+func sign(t Token, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s|%s|%d", t.Subject, t.Scope, t.ExpiresAt.UnixNano())
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
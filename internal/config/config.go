@@ -0,0 +1,180 @@
+// Package config parses a DAG's YAML definition into the types the
+// scheduler package executes against. It owns the on-disk schema, so a
+// DAG author's `container:`, `outputs:`, `secrets:`, `runsOn:`,
+// `logFormat:`/`logRotation:`, `metricsInterval:` and `resources:`
+// stanzas land on exactly the fields scheduler.Node reads off its
+// embedded *Step.
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DAG is the parsed form of a DAG definition file: a name and its
+// ordered steps.
+type DAG struct {
+	Name  string  `yaml:"name"`
+	Steps []*Step `yaml:"steps"`
+}
+
+// Load reads and parses the DAG definition at path.
+func Load(path string) (*DAG, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read dag file %s: %w", path, err)
+	}
+	var d DAG
+	if err := yaml.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("parse dag file %s: %w", path, err)
+	}
+	return &d, nil
+}
+
+// Step is one node of a DAG: the command to run and everything
+// scheduler.Node needs to execute it.
+type Step struct {
+	Name          string       `yaml:"name"`
+	Dir           string       `yaml:"dir"`
+	CmdWithArgs   string       `yaml:"command"`
+	Command       string       `yaml:"-"`
+	Args          []string     `yaml:"-"`
+	Script        string       `yaml:"script"`
+	Stdout        string       `yaml:"stdout"`
+	Output        string       `yaml:"output"`
+	Variables     []string     `yaml:"env"`
+	Preconditions []*Condition `yaml:"preconditions"`
+
+	// Container runs the step in Docker instead of on the scheduler host
+	// when set.
+	Container *Container `yaml:"container"`
+	// Outputs is the `outputs:` list, each entry a raw "type=...,dest=..."
+	// spec parsed by scheduler.parseOutputSpec.
+	Outputs []string `yaml:"outputs"`
+	// Secrets is the `secrets:` list, each entry a raw "NAME=scheme:ref"
+	// spec resolved by secrets.ResolveEnv.
+	Secrets []string `yaml:"secrets"`
+	// RunsOn selects the dagu-agent this step dispatches to instead of
+	// running locally, by the same selector AgentResolver matches against.
+	RunsOn string `yaml:"runsOn"`
+	// LogFormat switches a step's log from free-form text to structured
+	// JSON lines when set to "json".
+	LogFormat   string      `yaml:"logFormat"`
+	LogRotation LogRotation `yaml:"logRotation"`
+	// MetricsInterval is how often the step's resource usage is sampled;
+	// newMetricsRunner defaults this to 5s when zero.
+	MetricsInterval time.Duration `yaml:"-"`
+	Resources       ResourceLimits `yaml:"resources"`
+}
+
+// stepAlias is Step without its UnmarshalYAML method, so decoding into it
+// can't recurse back into Step.UnmarshalYAML.
+type stepAlias Step
+
+// UnmarshalYAML lets metricsInterval be written as a duration string
+// ("30s", "5m"), like gracePeriod and maxAge elsewhere in this file,
+// instead of a raw count of nanoseconds - yaml.v2 can't decode a
+// time.Duration field from a string on its own.
+func (s *Step) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw struct {
+		stepAlias       `yaml:",inline"`
+		MetricsInterval string `yaml:"metricsInterval"`
+	}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	*s = Step(raw.stepAlias)
+	if raw.MetricsInterval != "" {
+		d, err := time.ParseDuration(raw.MetricsInterval)
+		if err != nil {
+			return fmt.Errorf("parse metricsInterval %q: %w", raw.MetricsInterval, err)
+		}
+		s.MetricsInterval = d
+	}
+	return nil
+}
+
+// Condition is one `preconditions:` entry gating whether a step runs.
+type Condition struct {
+	Condition string `yaml:"condition"`
+	Expected  string `yaml:"expected"`
+}
+
+// Container is a step's `container:` config for running it under Docker
+// instead of directly on the scheduler host.
+type Container struct {
+	Image      string   `yaml:"image"`
+	User       string   `yaml:"user"`
+	Network    string   `yaml:"network"`
+	Mounts     []string `yaml:"mounts"`
+	Entrypoint string   `yaml:"entrypoint"`
+	// Pull is the image pull policy: "always", "missing" (the default
+	// when empty), or "never".
+	Pull string   `yaml:"pull"`
+	Env  []string `yaml:"env"`
+}
+
+// LogRotation is a step's `logRotation:` config, applied once
+// `logFormat: json` is set.
+type LogRotation struct {
+	MaxSizeBytes int64         `yaml:"maxSizeBytes"`
+	MaxAge       time.Duration `yaml:"-"`
+}
+
+func (r *LogRotation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw struct {
+		MaxSizeBytes int64  `yaml:"maxSizeBytes"`
+		MaxAge       string `yaml:"maxAge"`
+	}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	r.MaxSizeBytes = raw.MaxSizeBytes
+	if raw.MaxAge != "" {
+		d, err := time.ParseDuration(raw.MaxAge)
+		if err != nil {
+			return fmt.Errorf("parse maxAge %q: %w", raw.MaxAge, err)
+		}
+		r.MaxAge = d
+	}
+	return nil
+}
+
+// ResourceLimits are a step's `resources:` soft limits. Once
+// MaxRSSBytes or MaxCPUSeconds is exceeded, the node is signalled like a
+// user-requested cancel: SIGTERM first, escalating to SIGKILL if it
+// hasn't exited after GracePeriod.
+type ResourceLimits struct {
+	MaxRSSBytes   uint64        `yaml:"maxRssBytes"`
+	MaxCPUSeconds float64       `yaml:"maxCpuSeconds"`
+	GracePeriod   time.Duration `yaml:"-"`
+}
+
+func (r *ResourceLimits) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw struct {
+		MaxRSSBytes   uint64  `yaml:"maxRssBytes"`
+		MaxCPUSeconds float64 `yaml:"maxCpuSeconds"`
+		GracePeriod   string  `yaml:"gracePeriod"`
+	}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	r.MaxRSSBytes = raw.MaxRSSBytes
+	r.MaxCPUSeconds = raw.MaxCPUSeconds
+	if raw.GracePeriod != "" {
+		d, err := time.ParseDuration(raw.GracePeriod)
+		if err != nil {
+			return fmt.Errorf("parse gracePeriod %q: %w", raw.GracePeriod, err)
+		}
+		r.GracePeriod = d
+	}
+	return nil
+}
+
+// Enabled reports whether either limit is configured.
+func (r ResourceLimits) Enabled() bool {
+	return r.MaxRSSBytes > 0 || r.MaxCPUSeconds > 0
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad_AllStepFields(t *testing.T) {
+	yamlSrc := `
+name: test-dag
+steps:
+  - name: build
+    command: make build
+    dir: /work
+    runsOn: "zone=us-east"
+    logFormat: json
+    logRotation:
+      maxSizeBytes: 1048576
+      maxAge: 24h
+    metricsInterval: 30s
+    resources:
+      maxRssBytes: 536870912
+      maxCpuSeconds: 60
+      gracePeriod: 15s
+    outputs:
+      - "type=tar,dest=/artifacts/build.tar"
+    secrets:
+      - "API_KEY=vault:secret/data/build#key"
+    container:
+      image: golang:1.22
+      user: "1000:1000"
+      network: bridge
+      pull: always
+      mounts:
+        - /cache:/cache
+      env:
+        - GOFLAGS=-mod=vendor
+`
+	path := filepath.Join(t.TempDir(), "dag.yaml")
+	if err := os.WriteFile(path, []byte(yamlSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dag, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(dag.Steps) != 1 {
+		t.Fatalf("got %d steps, want 1", len(dag.Steps))
+	}
+	s := dag.Steps[0]
+
+	if s.RunsOn != "zone=us-east" {
+		t.Errorf("RunsOn = %q", s.RunsOn)
+	}
+	if s.LogFormat != "json" {
+		t.Errorf("LogFormat = %q", s.LogFormat)
+	}
+	if s.LogRotation.MaxSizeBytes != 1048576 || s.LogRotation.MaxAge != 24*time.Hour {
+		t.Errorf("LogRotation = %+v", s.LogRotation)
+	}
+	if s.MetricsInterval != 30*time.Second {
+		t.Errorf("MetricsInterval = %v", s.MetricsInterval)
+	}
+	if s.Resources.MaxRSSBytes != 536870912 || s.Resources.MaxCPUSeconds != 60 || s.Resources.GracePeriod != 15*time.Second {
+		t.Errorf("Resources = %+v", s.Resources)
+	}
+	if !s.Resources.Enabled() {
+		t.Error("Resources.Enabled() = false, want true")
+	}
+	if len(s.Outputs) != 1 || s.Outputs[0] != "type=tar,dest=/artifacts/build.tar" {
+		t.Errorf("Outputs = %v", s.Outputs)
+	}
+	if len(s.Secrets) != 1 || s.Secrets[0] != "API_KEY=vault:secret/data/build#key" {
+		t.Errorf("Secrets = %v", s.Secrets)
+	}
+	if s.Container == nil {
+		t.Fatal("Container = nil, want set")
+	}
+	if s.Container.Image != "golang:1.22" || s.Container.Pull != "always" || s.Container.Network != "bridge" {
+		t.Errorf("Container = %+v", s.Container)
+	}
+	if len(s.Container.Mounts) != 1 || len(s.Container.Env) != 1 {
+		t.Errorf("Container mounts/env = %+v", s.Container)
+	}
+}
+
+func TestLoad_StepWithoutNewFields(t *testing.T) {
+	yamlSrc := `
+name: plain-dag
+steps:
+  - name: hello
+    command: echo hi
+`
+	path := filepath.Join(t.TempDir(), "dag.yaml")
+	if err := os.WriteFile(path, []byte(yamlSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dag, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s := dag.Steps[0]
+	if s.Container != nil {
+		t.Errorf("Container = %+v, want nil", s.Container)
+	}
+	if s.Resources.Enabled() {
+		t.Error("Resources.Enabled() = true for a step with no resources: stanza")
+	}
+	if s.MetricsInterval != 0 {
+		t.Errorf("MetricsInterval = %v, want 0", s.MetricsInterval)
+	}
+}
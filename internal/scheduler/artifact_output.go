@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"archive/tar"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tarExporter archives the step's working directory (n.Dir) into a tar
+// file at dest. Like artifactDirExporter it ignores the captured stdout
+// entirely - it is meant for shipping a whole directory's worth of build
+// artifacts, not a single value.
+type tarExporter struct{}
+
+func (tarExporter) Export(n *Node, dest string, _ []byte) error {
+	destPath := resolveDest(n, dest)
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	tw := tar.NewWriter(out)
+	defer tw.Close()
+
+	return filepath.WalkDir(n.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// dest commonly lives inside n.Dir (e.g. `dest: out.tar` next to
+		// the step's other files); skip it explicitly so the archive
+		// being written isn't also read back into itself mid-write.
+		if isOrUnder(path, destPath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(n.Dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+// artifactDirExporter copies the step's working directory tree to dest,
+// preserving relative paths. Unlike tarExporter it leaves the files as
+// loose files rather than one archive, so they can be picked up directly
+// by a later step.
+type artifactDirExporter struct{}
+
+func (artifactDirExporter) Export(n *Node, dest string, _ []byte) error {
+	destDir := resolveDest(n, dest)
+	return filepath.WalkDir(n.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// destDir frequently lives inside n.Dir; once created below it
+		// would otherwise show up as a child of n.Dir and get walked
+		// (and copied into itself) along with everything else, recursing
+		// without bound. Skip the destination subtree entirely.
+		if isOrUnder(path, destDir) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(n.Dir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		_, err = io.Copy(out, in)
+		return err
+	})
+}
+
+// isOrUnder reports whether path is dest itself or a descendant of it.
+func isOrUnder(path, dest string) bool {
+	path = filepath.Clean(path)
+	dest = filepath.Clean(dest)
+	return path == dest || strings.HasPrefix(path, dest+string(os.PathSeparator))
+}
@@ -0,0 +1,220 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// DockerRuntime runs a step's command inside a container instead of
+// directly on the scheduler host. It shells out to the `docker` CLI
+// rather than linking against the Docker SDK, matching dagu's existing
+// preference for wrapping well-known CLIs over vendoring client libraries.
+type DockerRuntime struct {
+	containerName string
+}
+
+func (r *DockerRuntime) Start(ctx context.Context, n *Node, stdout, stderr io.Writer) error {
+	c := n.Container
+
+	if err := ensureImage(ctx, c.Image, c.Pull); err != nil {
+		return err
+	}
+
+	passwdFile, groupFile, err := writePasswdGroupFiles(n.Dir, c.User)
+	if err != nil {
+		return fmt.Errorf("prepare passwd/group files: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(passwdFile)
+		_ = os.Remove(groupFile)
+	}()
+
+	// n.secretEnv carries values resolved from secrets.ResolveEnv; passing
+	// those as `-e` puts them in the docker client process's argv, visible
+	// to any local user via ps/`/proc/<pid>/cmdline` for its whole
+	// lifetime. Write every env var to a file and use --env-file instead.
+	env := append(append(append([]string{}, n.Variables...), n.secretEnv...), c.Env...)
+	envFile, err := writeEnvFile(n.Dir, env)
+	if err != nil {
+		return fmt.Errorf("prepare container env file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(envFile)
+	}()
+
+	r.containerName = fmt.Sprintf("dagu-%s-%s", utils.ValidFilename(n.Name, "_"), utils.TruncString(n.requestId, 8))
+	args := dockerRunArgs(n, r.containerName, passwdFile, groupFile, envFile)
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = n.Dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	n.mu.Lock()
+	n.cmd = cmd
+	n.mu.Unlock()
+
+	return cmd.Run()
+}
+
+// dockerRunArgs assembles the `docker run` argument list for a step's
+// container config, mounting the generated passwd/group files so scripts
+// that call functions like getpwuid() resolve to a real-looking username
+// even when --user is an arbitrary uid:gid pair. Env vars (including any
+// resolved secret values) are passed via --env-file rather than -e so
+// they never land in the docker client process's argv.
+func dockerRunArgs(n *Node, containerName, passwdFile, groupFile, envFile string) []string {
+	c := n.Container
+	args := []string{"run", "--rm", "--name", containerName}
+
+	if c.User != "" {
+		args = append(args, "--user", c.User)
+	}
+	if c.Network != "" {
+		args = append(args, "--network", c.Network)
+	}
+	for _, m := range c.Mounts {
+		args = append(args, "-v", m)
+	}
+	args = append(args,
+		"-v", fmt.Sprintf("%s:/etc/passwd:ro", passwdFile),
+		"-v", fmt.Sprintf("%s:/etc/group:ro", groupFile),
+		"--env-file", envFile,
+	)
+	if n.Dir != "" {
+		args = append(args, "-w", n.Dir)
+	}
+	args = append(args, c.Image)
+	if c.Entrypoint != "" {
+		args = append(args, strings.Fields(c.Entrypoint)...)
+	}
+	args = append(args, n.Command)
+	args = append(args, n.Args...)
+	return args
+}
+
+// ensureImage applies a step's `container.pull` policy before `docker
+// run` gets a chance to implicitly (and inconsistently, depending on the
+// host's daemon config) pull on its own:
+//   - "always": unconditionally `docker pull` the image
+//   - "missing" (the default when unset): pull only if `docker image
+//     inspect` doesn't already find it locally
+//   - "never": never pull; a missing image is left for `docker run` to
+//     fail on
+func ensureImage(ctx context.Context, image, policy string) error {
+	if policy == "never" {
+		return nil
+	}
+	if policy != "always" {
+		if exec.CommandContext(ctx, "docker", "image", "inspect", image).Run() == nil {
+			return nil
+		}
+	}
+	if err := exec.CommandContext(ctx, "docker", "pull", image).Run(); err != nil {
+		return fmt.Errorf("pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+// samplePID returns the host-pid-namespace pid of the container's own
+// init process, so metricsRunner samples the actual containerized
+// workload instead of n.cmd's pid - the local `docker run` CLI client,
+// which sits idle for the container's whole lifetime and would otherwise
+// report near-zero usage regardless of what's running inside it.
+func (r *DockerRuntime) samplePID() int {
+	if r.containerName == "" {
+		return 0
+	}
+	out, err := exec.Command("docker", "inspect", "--format", "{{.State.Pid}}", r.containerName).Output()
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+func (r *DockerRuntime) Signal(n *Node, sig os.Signal) error {
+	if n.cmd == nil || n.cmd.Process == nil {
+		return nil
+	}
+	// Every signal goes to the container via `docker kill` rather than to
+	// the local `docker run` client process, so the container itself
+	// receives it and is torn down rather than just the CLI client.
+	// `docker kill --signal` wants a signal name like SIGTERM, not Go's
+	// sig.String() (which returns "terminated"/"killed").
+	return exec.Command("docker", "kill", "--signal", signalName(sig), r.containerName).Run()
+}
+
+// writeEnvFile writes env ("NAME=value" entries) to a tempfile suitable
+// for `docker run --env-file`, one entry per line. Callers are
+// responsible for removing it once the container has started.
+func writeEnvFile(dir string, env []string) (string, error) {
+	f, err := os.CreateTemp(dir, "dagu_env-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	for _, e := range env {
+		if _, err := fmt.Fprintln(f, e); err != nil {
+			_ = os.Remove(f.Name())
+			return "", err
+		}
+	}
+	return filepath.Clean(f.Name()), nil
+}
+
+// writePasswdGroupFiles generates minimal /etc/passwd and /etc/group
+// tempfiles holding a single entry that maps the given "uid:gid" spec to
+// the invoking host user's name (or "dagu"), so tools that shell out to
+// `whoami` don't choke on an unknown uid inside the container.
+func writePasswdGroupFiles(dir, spec string) (passwdFile, groupFile string, err error) {
+	uid, gid := "0", "0"
+	if spec != "" {
+		parts := strings.SplitN(spec, ":", 2)
+		uid = parts[0]
+		if len(parts) > 1 {
+			gid = parts[1]
+		} else {
+			gid = uid
+		}
+	}
+
+	name := "dagu"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+
+	pf, err := os.CreateTemp(dir, "dagu_passwd-")
+	if err != nil {
+		return "", "", err
+	}
+	defer pf.Close()
+	if _, err := pf.WriteString(fmt.Sprintf("%s:x:%s:%s::/tmp:/bin/sh\n", name, uid, gid)); err != nil {
+		return "", "", err
+	}
+
+	gf, err := os.CreateTemp(dir, "dagu_group-")
+	if err != nil {
+		_ = os.Remove(pf.Name())
+		return "", "", err
+	}
+	defer gf.Close()
+	if _, err := gf.WriteString(fmt.Sprintf("%s:x:%s:\n", name, gid)); err != nil {
+		_ = os.Remove(pf.Name())
+		return "", "", err
+	}
+
+	return filepath.Clean(pf.Name()), filepath.Clean(gf.Name()), nil
+}
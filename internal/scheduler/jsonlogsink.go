@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// RotationPolicy controls when a jsonLogSink rolls its underlying file
+// over to a new segment. A zero value disables rotation entirely.
+type RotationPolicy struct {
+	MaxSizeBytes int64         // rotate once the current segment exceeds this size
+	MaxAge       time.Duration // rotate once the current segment is older than this
+}
+
+func (p RotationPolicy) enabled() bool {
+	return p.MaxSizeBytes > 0 || p.MaxAge > 0
+}
+
+// jsonLogSink is the default LogSink. It writes newline-delimited JSON
+// events to disk and, once a rotation threshold is crossed, renames the
+// current segment aside and gzips it before opening a fresh one.
+type jsonLogSink struct {
+	mu       sync.Mutex
+	path     string
+	policy   RotationPolicy
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewJSONLogSink creates a LogSink that writes to path, rotating segments
+// according to policy. The directory containing path must already exist.
+func NewJSONLogSink(path string, policy RotationPolicy) (LogSink, error) {
+	s := &jsonLogSink{path: path, policy: policy}
+	if err := s.openCurrent(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *jsonLogSink) openCurrent() error {
+	f, err := utils.OpenOrCreateFile(s.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	s.openedAt = time.Now()
+	return nil
+}
+
+func (s *jsonLogSink) Write(ev *LogEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.policy.enabled() && s.shouldRotate() {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	b, err := encodeEvent(ev)
+	if err != nil {
+		return err
+	}
+	n, err := s.file.Write(b)
+	s.size += int64(n)
+	return err
+}
+
+func (s *jsonLogSink) shouldRotate() bool {
+	if s.policy.MaxSizeBytes > 0 && s.size >= s.policy.MaxSizeBytes {
+		return true
+	}
+	if s.policy.MaxAge > 0 && time.Since(s.openedAt) >= s.policy.MaxAge {
+		return true
+	}
+	return false
+}
+
+// rotate closes the current segment, gzips it under a timestamped name,
+// and opens a fresh segment at the original path.
+func (s *jsonLogSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	rotated := fmt.Sprintf("%s.%s", s.path, time.Now().Format("20060102.150405.000"))
+	if err := os.Rename(s.path, rotated); err != nil {
+		return err
+	}
+	if err := gzipFile(rotated); err != nil {
+		return err
+	}
+	return s.openCurrent()
+}
+
+func (s *jsonLogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// gzipFile compresses src in place, replacing it with "src.gz" and
+// removing the uncompressed original.
+func gzipFile(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(src + ".gz")
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gw := gzip.NewWriter(out)
+	if _, err := io.Copy(gw, in); err != nil {
+		_ = gw.Close()
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogEvent is a single structured log line emitted by a running Node.
+// It is the unit written to a LogSink.
+type LogEvent struct {
+	Timestamp time.Time `json:"ts"`
+	Node      string    `json:"node"`
+	Step      string    `json:"step"`
+	Stream    string    `json:"stream"`
+	Retry     int       `json:"retry"`
+	RequestId string    `json:"requestId"`
+	Msg       string    `json:"msg"`
+}
+
+// LogSink receives structured log events produced by a Node while it runs
+// and is responsible for persisting them somewhere (disk, network, etc).
+// Implementations must be safe for concurrent use since stdout and stderr
+// are written to from separate goroutines spawned by exec.Cmd.
+type LogSink interface {
+	Write(ev *LogEvent) error
+	Close() error
+}
+
+// sinkWriter adapts an io.Writer-based stream (as required by exec.Cmd)
+// into structured events delivered to a LogSink. It buffers partial lines
+// across writes so that a single LogEvent always contains one full line.
+type sinkWriter struct {
+	sink   LogSink
+	node   string
+	step   string
+	stream string
+	reqId  string
+
+	retryCount func() int
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// newSinkWriter returns an io.Writer that emits one LogEvent per line
+// written to it, tagged with the given node/step/stream metadata.
+func newSinkWriter(sink LogSink, node, step, stream, requestId string, retryCount func() int) *sinkWriter {
+	return &sinkWriter{
+		sink:       sink,
+		node:       node,
+		step:       step,
+		stream:     stream,
+		reqId:      requestId,
+		retryCount: retryCount,
+	}
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// incomplete line; put it back for the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+		if emitErr := w.emit(line[:len(line)-1]); emitErr != nil {
+			return 0, emitErr
+		}
+	}
+	return len(p), nil
+}
+
+func (w *sinkWriter) emit(line string) error {
+	retry := 0
+	if w.retryCount != nil {
+		retry = w.retryCount()
+	}
+	return w.sink.Write(&LogEvent{
+		Timestamp: time.Now(),
+		Node:      w.node,
+		Step:      w.step,
+		Stream:    w.stream,
+		Retry:     retry,
+		RequestId: w.reqId,
+		Msg:       line,
+	})
+}
+
+// Flush pushes out any buffered partial line as a final event. It is
+// called from Node.Execute once the command has exited so that output
+// not terminated by a newline is not silently dropped.
+func (w *sinkWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := w.buf.String()
+	w.buf.Reset()
+	return w.emit(line)
+}
+
+var _ io.Writer = (*sinkWriter)(nil)
+
+// encodeEvent marshals a LogEvent as a single newline-delimited JSON line.
+func encodeEvent(ev *LogEvent) ([]byte, error) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,239 @@
+package scheduler
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/yohamta/dagu/internal/config"
+)
+
+// Metrics is a Node's resource usage, sampled while it runs and
+// finalized once it exits. It lets operators see which step in a DAG was
+// the CPU/memory/IO hog.
+type Metrics struct {
+	PeakRSSBytes uint64        `json:"peakRssBytes"`
+	CPUSeconds   float64       `json:"cpuSeconds"`
+	IOReadBytes  uint64        `json:"ioReadBytes"`
+	IOWriteBytes uint64        `json:"ioWriteBytes"`
+	WallTime     time.Duration `json:"wallTime"`
+}
+
+// ResourceLimits are defined in internal/config (config.ResourceLimits)
+// since they're parsed off a step's `resources:` YAML stanza; aliased
+// here so the rest of this file reads the same as before that package
+// existed.
+type ResourceLimits = config.ResourceLimits
+
+// metricsSample is one line of the metricsWriter JSON-lines output.
+type metricsSample struct {
+	Timestamp time.Time `json:"ts"`
+	Node      string    `json:"node"`
+	Metrics   Metrics   `json:"metrics"`
+}
+
+// metricsRunner samples a node's process group on an interval until the
+// node stops running, writing each sample to a JSON-lines file alongside
+// the node's log and enforcing n.Resources soft limits along the way.
+type metricsRunner struct {
+	n        *Node
+	interval time.Duration
+	writer   *bufio.Writer
+	file     *os.File
+
+	mu      sync.Mutex
+	current Metrics
+
+	escalating bool // guards against piling up a SIGKILL goroutine per over-limit tick
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newMetricsRunner(n *Node, logPath string, interval time.Duration) (*metricsRunner, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	f, err := os.Create(metricsPathFor(logPath))
+	if err != nil {
+		return nil, err
+	}
+	return &metricsRunner{
+		n:        n,
+		interval: interval,
+		file:     f,
+		writer:   bufio.NewWriter(f),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func metricsPathFor(logPath string) string {
+	return strings.TrimSuffix(logPath, filepath.Ext(logPath)) + ".metrics.jsonl"
+}
+
+// Start begins sampling in the background. Call Stop once the node's
+// command has exited.
+func (m *metricsRunner) Start() {
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.sampleOnce()
+			}
+		}
+	}()
+}
+
+func (m *metricsRunner) sampleOnce() {
+	pid := m.n.processGroupPID()
+	if pid <= 0 {
+		return
+	}
+	snap, err := sampleProcessGroup(pid)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	if snap.RSSBytes > m.current.PeakRSSBytes {
+		m.current.PeakRSSBytes = snap.RSSBytes
+	}
+	m.current.CPUSeconds = snap.CPUSeconds
+	m.current.IOReadBytes = snap.IOReadBytes
+	m.current.IOWriteBytes = snap.IOWriteBytes
+	m.current.WallTime = time.Since(m.n.StartedAt)
+	cur := m.current
+	m.mu.Unlock()
+
+	m.emit(cur)
+	m.enforceLimits(cur)
+}
+
+func (m *metricsRunner) emit(snapshot Metrics) {
+	b, err := json.Marshal(metricsSample{Timestamp: time.Now(), Node: m.n.Name, Metrics: snapshot})
+	if err != nil {
+		return
+	}
+	_, _ = m.writer.Write(append(b, '\n'))
+	_ = m.writer.Flush()
+}
+
+func (m *metricsRunner) enforceLimits(snapshot Metrics) {
+	limits := m.n.Resources
+	if !limits.Enabled() {
+		return
+	}
+	over := (limits.MaxRSSBytes > 0 && snapshot.PeakRSSBytes > limits.MaxRSSBytes) ||
+		(limits.MaxCPUSeconds > 0 && snapshot.CPUSeconds > limits.MaxCPUSeconds)
+	if !over {
+		return
+	}
+
+	m.mu.Lock()
+	if m.escalating {
+		// Already SIGTERM'd and waiting out the grace period for a prior
+		// over-limit tick; don't pile up another SIGKILL goroutine for
+		// every sample still over the limit in the meantime.
+		m.mu.Unlock()
+		return
+	}
+	m.escalating = true
+	m.mu.Unlock()
+
+	grace := limits.GracePeriod
+	if grace <= 0 {
+		grace = 10 * time.Second
+	}
+	pid := m.n.processGroupPID()
+	m.n.signal(syscall.SIGTERM)
+	go func() {
+		time.Sleep(grace)
+		// n.Status flips to NodeStatus_Cancel the instant signal() is
+		// called, whether or not the process has actually exited, so it
+		// can't tell us anything here - check the pid directly instead.
+		if processAlive(pid) {
+			m.n.signal(syscall.SIGKILL)
+		}
+		m.mu.Lock()
+		m.escalating = false
+		m.mu.Unlock()
+	}()
+}
+
+// Stop halts sampling and takes one final, authoritative sample from the
+// node's own exited process (via cmd.ProcessState.SysUsage()), which -
+// unlike /proc polling - can't miss a burst of usage between the last
+// tick and process exit, and unlike getrusage(RUSAGE_CHILDREN) isn't
+// contaminated by other nodes the scheduler has run before this one.
+func (m *metricsRunner) Stop() Metrics {
+	close(m.stop)
+	<-m.done
+
+	if final, err := finalRusageSnapshot(m.n); err == nil {
+		m.mu.Lock()
+		if final.RSSBytes > m.current.PeakRSSBytes {
+			m.current.PeakRSSBytes = final.RSSBytes
+		}
+		if final.CPUSeconds > m.current.CPUSeconds {
+			m.current.CPUSeconds = final.CPUSeconds
+		}
+		m.current.WallTime = time.Since(m.n.StartedAt)
+		snap := m.current
+		m.mu.Unlock()
+		m.emit(snap)
+	}
+
+	_ = m.writer.Flush()
+	_ = m.file.Close()
+	return m.current
+}
+
+// processAlive reports whether pid still exists, by sending it the null
+// signal (0) rather than an actual one. This is the standard way to probe
+// liveness without side effects: the kernel still validates permissions
+// and existence, so ESRCH unambiguously means the process is gone.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// processSampler is implemented by a Runtime whose n.cmd pid isn't the
+// right thing to sample - e.g. DockerRuntime, where n.cmd is the local
+// `docker run` CLI client rather than the containerized workload it
+// launched.
+type processSampler interface {
+	samplePID() int
+}
+
+// processGroupPID returns the pid that roots the node's process group, or
+// 0 if the node isn't running a local/containerized process (e.g. it's a
+// RemoteRuntime step, whose process runs on the agent's host and is not
+// sampled).
+func (n *Node) processGroupPID() int {
+	n.mu.RLock()
+	runtime := n.runtime
+	cmd := n.cmd
+	n.mu.RUnlock()
+
+	if ps, ok := runtime.(processSampler); ok {
+		return ps.samplePID()
+	}
+	if cmd == nil || cmd.Process == nil {
+		return 0
+	}
+	return cmd.Process.Pid
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestProcessAlive(t *testing.T) {
+	cmd := exec.Command("sleep", "5")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("could not start test process: %v", err)
+	}
+	pid := cmd.Process.Pid
+
+	if !processAlive(pid) {
+		t.Fatalf("expected pid %d to be alive", pid)
+	}
+
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+
+	if processAlive(pid) {
+		t.Fatalf("expected pid %d to be reported dead after exit", pid)
+	}
+}
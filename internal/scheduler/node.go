@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/yohamta/dagu/internal/config"
+	"github.com/yohamta/dagu/internal/secrets"
 	"github.com/yohamta/dagu/internal/utils"
 )
 
@@ -50,6 +51,12 @@ func (s NodeStatus) String() string {
 }
 
 // Node is a node in a DAG. It executes a command.
+//
+// Node reads several fields off the embedded *config.Step - Container,
+// Outputs, Secrets, RunsOn, LogFormat, LogRotation, MetricsInterval and
+// Resources - that internal/config.Load parses out of a DAG YAML's
+// `container:`, `outputs:`, `secrets:`, `runsOn:`, `logFormat:`/
+// `logRotation:`, `metricsInterval:` and `resources:` stanzas.
 type Node struct {
 	*config.Step
 	NodeState
@@ -57,6 +64,7 @@ type Node struct {
 	id           int
 	mu           sync.RWMutex
 	cmd          *exec.Cmd
+	runtime      Runtime
 	cancelFunc   func()
 	logFile      *os.File
 	logWriter    *bufio.Writer
@@ -65,6 +73,13 @@ type Node struct {
 	outputWriter *os.File
 	outputReader *os.File
 	scriptFile   *os.File
+	logSink      LogSink
+	stdoutSink   *sinkWriter
+	stderrSink   *sinkWriter
+	requestId    string
+	secretEnv    []string
+	secretValues []string
+	metrics      *metricsRunner
 	done         bool
 }
 
@@ -78,58 +93,111 @@ type NodeState struct {
 	RetriedAt  time.Time
 	DoneCount  int
 	Error      error
+	Metrics    Metrics
 }
 
-// Execute runs the command synchronously and returns error if any.
+// Execute runs the command synchronously and returns error if any. A step
+// with a `runsOn:` selector is dispatched to a remote dagu-agent; otherwise
+// a step with a `container:` config runs inside a Docker container, and
+// any other step runs locally via exec.CommandContext (see Runtime /
+// newRuntime).
 func (n *Node) Execute() error {
 	ctx, fn := context.WithCancel(context.Background())
 	n.cancelFunc = fn
 	if n.CmdWithArgs != "" {
 		n.Command, n.Args = utils.SplitCommand(os.ExpandEnv(n.CmdWithArgs))
 	}
-	args := n.Args
-	if n.scriptFile != nil {
-		args = []string{}
-		args = append(args, n.Args...)
-		args = append(args, n.scriptFile.Name())
-	}
-	n.cmd = exec.CommandContext(ctx, n.Command, args...)
-	cmd := n.cmd
-	cmd.Dir = n.Dir
-	cmd.Env = append(cmd.Env, n.Variables...)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
-	}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stdout
+	var stdout io.Writer = os.Stdout
+	var stderr io.Writer = os.Stdout
 
 	if n.logWriter != nil {
-		cmd.Stdout = n.logWriter
-		cmd.Stderr = n.logWriter
+		stdout = n.logWriter
+		stderr = n.logWriter
 	}
 
 	if n.stdoutWriter != nil {
-		cmd.Stdout = io.MultiWriter(n.logWriter, n.stdoutWriter)
+		stdout = io.MultiWriter(n.logWriter, n.stdoutWriter)
+	}
+
+	if n.logSink != nil {
+		// Structured logging replaces the free-form writers above: stdout
+		// and stderr are captured separately so each event carries an
+		// accurate stream tag instead of being interleaved in one file.
+		stdout = n.stdoutSink
+		stderr = n.stderrSink
+		if n.stdoutWriter != nil {
+			stdout = io.MultiWriter(n.stdoutSink, n.stdoutWriter)
+		}
 	}
 
-	if n.Output != "" {
+	var stdoutRedactor, stderrRedactor *redactingWriter
+	if len(n.secretValues) > 0 {
+		// Redact before the output-capture pipe branches off below, so
+		// secrets stay out of n.Log/n.Stdout but are still available,
+		// unredacted, to an `output:`/`outputs:` consumer that asked for
+		// the raw value.
+		stdoutRedactor = newRedactingWriter(stdout, n.secretValues)
+		stderrRedactor = newRedactingWriter(stderr, n.secretValues)
+		stdout = stdoutRedactor
+		stderr = stderrRedactor
+	}
+
+	capturingOutput := n.Output != "" || len(n.Outputs) > 0
+	if capturingOutput {
 		var err error
 		if n.outputReader, n.outputWriter, err = os.Pipe(); err != nil {
 			return err
 		}
-		cmd.Stdout = io.MultiWriter(cmd.Stdout, n.outputWriter)
+		stdout = io.MultiWriter(stdout, n.outputWriter)
+	}
+
+	if n.Log != "" {
+		if runner, err := newMetricsRunner(n, n.Log, n.MetricsInterval); err != nil {
+			utils.LogErr("start metrics sampler", err)
+		} else {
+			n.metrics = runner
+			n.metrics.Start()
+		}
 	}
 
-	n.Error = cmd.Run()
+	n.runtime = newRuntime(n)
+	n.Error = n.runtime.Start(ctx, n, stdout, stderr)
 
-	if n.outputReader != nil && n.Output != "" {
+	if n.metrics != nil {
+		n.Metrics = n.metrics.Stop()
+	}
+
+	if stdoutRedactor != nil {
+		utils.LogErr("flush stdout redaction buffer", stdoutRedactor.Close())
+	}
+	if stderrRedactor != nil {
+		utils.LogErr("flush stderr redaction buffer", stderrRedactor.Close())
+	}
+
+	if n.stdoutSink != nil {
+		utils.LogErr("flush stdout log sink", n.stdoutSink.Flush())
+	}
+	if n.stderrSink != nil {
+		utils.LogErr("flush stderr log sink", n.stderrSink.Flush())
+	}
+
+	if n.outputReader != nil && capturingOutput {
 		utils.LogErr("close pipe writer", n.outputWriter.Close())
 		var buf bytes.Buffer
 		_, _ = io.Copy(&buf, n.outputReader)
-		ret := buf.String()
-		os.Setenv(n.Output, strings.TrimSpace(ret))
+		data := buf.Bytes()
+		if n.Output != "" {
+			os.Setenv(n.Output, strings.TrimSpace(string(data)))
+		}
+		if len(n.Outputs) > 0 {
+			if err := n.exportOutputs(data); err != nil {
+				utils.LogErr("export outputs", err)
+				if n.Error == nil {
+					n.Error = err
+				}
+			}
+		}
 	}
 
 	return n.Error
@@ -181,9 +249,13 @@ func (n *Node) signal(sig os.Signal) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	status := n.Status
-	if status == NodeStatus_Running && n.cmd != nil {
+	// Status flips to NodeStatus_Cancel as soon as the first signal goes
+	// out, so a second call (e.g. metrics' SIGTERM-then-SIGKILL escalation)
+	// must still be allowed through on Cancel - otherwise the escalation
+	// call is silently swallowed by this gate and never reaches the runtime.
+	if (status == NodeStatus_Running || status == NodeStatus_Cancel) && n.cmd != nil && n.runtime != nil {
 		log.Printf("Sending %s signal to %s", sig, n.Name)
-		utils.LogErr("sending signal", syscall.Kill(-n.cmd.Process.Pid, sig.(syscall.Signal)))
+		utils.LogErr("sending signal", n.runtime.Signal(n, sig))
 	}
 	if status == NodeStatus_Running {
 		n.Status = NodeStatus_Cancel
@@ -194,6 +266,13 @@ func (n *Node) cancel() {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	status := n.Status
+	if status == NodeStatus_Running && n.runtime != nil {
+		// Cancelling ctx alone only stops a local exec.CommandContext;
+		// DockerRuntime/RemoteRuntime run the real work out of process
+		// (a container, a remote agent), so without an explicit signal
+		// here a cancelled DAG would leave that work running.
+		utils.LogErr("signalling node on cancel", n.runtime.Signal(n, syscall.SIGTERM))
+	}
 	if status == NodeStatus_Running {
 		n.Status = NodeStatus_Cancel
 	}
@@ -205,6 +284,7 @@ func (n *Node) cancel() {
 
 func (n *Node) setup(logDir string, requestId string) error {
 	n.StartedAt = time.Now()
+	n.requestId = requestId
 	n.Log = filepath.Join(logDir, fmt.Sprintf("%s.%s.%s.log",
 		utils.ValidFilename(n.Name, "_"),
 		n.StartedAt.Format("20060102.15:04:05.000"),
@@ -212,8 +292,10 @@ func (n *Node) setup(logDir string, requestId string) error {
 	))
 	setup := []func() error{
 		n.setupLog,
+		n.setupLogSink,
 		n.setupStdout,
 		n.setupScript,
+		n.setupSecrets,
 	}
 	for _, fn := range setup {
 		err := fn()
@@ -270,6 +352,48 @@ func (n *Node) setupLog() error {
 	return nil
 }
 
+// setupLogSink wires up structured JSON logging when the step opts in via
+// `logFormat: json`. It leaves n.logSink nil (and Execute falls back to the
+// plain-text n.logWriter) for steps that don't request it.
+func (n *Node) setupLogSink() error {
+	if n.LogFormat != "json" || n.Log == "" {
+		return nil
+	}
+	policy := RotationPolicy{
+		MaxSizeBytes: n.LogRotation.MaxSizeBytes,
+		MaxAge:       n.LogRotation.MaxAge,
+	}
+	sink, err := NewJSONLogSink(n.Log+".jsonl", policy)
+	if err != nil {
+		n.Error = err
+		return err
+	}
+	n.logSink = sink
+	n.stdoutSink = newSinkWriter(sink, n.Name, n.Name, "stdout", n.requestId, n.ReadRetryCount)
+	n.stderrSink = newSinkWriter(sink, n.Name, n.Name, "stderr", n.requestId, n.ReadRetryCount)
+	return nil
+}
+
+// setupSecrets resolves the step's `secrets:` list (each entry shaped
+// `NAME=scheme:ref`, e.g. `DB_PASSWORD=vault:secret/data/db#password`)
+// into environment assignments and records the resolved values so they
+// can be redacted from logs. Resolution happens here, once per run,
+// rather than per-write, since providers like Vault/AWS/GCP make a
+// network call.
+func (n *Node) setupSecrets() error {
+	if len(n.Secrets) == 0 {
+		return nil
+	}
+	env, values, err := secrets.ResolveEnv(context.Background(), n.Secrets)
+	if err != nil {
+		n.Error = err
+		return err
+	}
+	n.secretEnv = env
+	n.secretValues = values
+	return nil
+}
+
 func (n *Node) teardown() error {
 	if n.done {
 		return nil
@@ -295,6 +419,13 @@ func (n *Node) teardown() error {
 	if n.scriptFile != nil {
 		_ = os.Remove(n.scriptFile.Name())
 	}
+
+	if n.logSink != nil {
+		if err := n.logSink.Close(); err != nil {
+			lastErr = err
+		}
+	}
+
 	if lastErr != nil {
 		n.Error = lastErr
 	}
@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// OutputSpec is a single entry of a step's `outputs:` list, parsed from the
+// `type=...,dest=...` syntax, e.g. `type=file,dest=/tmp/result.txt`.
+type OutputSpec struct {
+	Type string
+	Dest string
+}
+
+// parseOutputSpec parses one `outputs:` list entry.
+func parseOutputSpec(raw string) (OutputSpec, error) {
+	var spec OutputSpec
+	for _, field := range strings.Split(raw, ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return spec, fmt.Errorf("invalid output spec field %q in %q", field, raw)
+		}
+		switch kv[0] {
+		case "type":
+			spec.Type = kv[1]
+		case "dest":
+			spec.Dest = kv[1]
+		default:
+			return spec, fmt.Errorf("unknown output spec key %q in %q", kv[0], raw)
+		}
+	}
+	if spec.Type == "" {
+		return spec, fmt.Errorf("output spec %q is missing type=", raw)
+	}
+	return spec, nil
+}
+
+// outputExporter writes a step's captured stdout somewhere once it has
+// finished running. A single step can list multiple outputs of different
+// types, each handled by its own exporter.
+type outputExporter interface {
+	Export(n *Node, dest string, data []byte) error
+}
+
+// outputExporters is the registry of exporter implementations keyed by
+// `type:`. New types can be added with RegisterOutputExporter without
+// touching Node.Execute.
+var outputExporters = map[string]outputExporter{
+	"env":          envExporter{},
+	"file":         fileExporter{},
+	"tar":          tarExporter{},
+	"json":         jsonExporter{},
+	"artifact-dir": artifactDirExporter{},
+}
+
+// RegisterOutputExporter adds or overrides the exporter used for a given
+// output `type:`.
+func RegisterOutputExporter(typ string, e outputExporter) {
+	outputExporters[typ] = e
+}
+
+// exportOutputs runs every entry of n.Outputs (in addition to the legacy
+// single `output:` env var, handled separately in Execute) against the
+// step's captured stdout.
+func (n *Node) exportOutputs(data []byte) error {
+	for _, raw := range n.Outputs {
+		spec, err := parseOutputSpec(raw)
+		if err != nil {
+			return err
+		}
+		exp, ok := outputExporters[spec.Type]
+		if !ok {
+			return fmt.Errorf("unknown output type %q", spec.Type)
+		}
+		if err := exp.Export(n, spec.Dest, data); err != nil {
+			return fmt.Errorf("export %s to %s: %w", spec.Type, spec.Dest, err)
+		}
+	}
+	return nil
+}
+
+// envExporter stashes the trimmed output in an environment variable. This
+// is the same behavior as the legacy `output:` field.
+type envExporter struct{}
+
+func (envExporter) Export(n *Node, dest string, data []byte) error {
+	return os.Setenv(dest, strings.TrimSpace(string(data)))
+}
+
+// fileExporter writes the raw captured output to a file.
+type fileExporter struct{}
+
+func (fileExporter) Export(n *Node, dest string, data []byte) error {
+	return os.WriteFile(resolveDest(n, dest), data, 0644)
+}
+
+// jsonExporter wraps the captured output in a small JSON summary.
+type jsonExporter struct{}
+
+func (jsonExporter) Export(n *Node, dest string, data []byte) error {
+	summary := fmt.Sprintf(
+		"{\"node\":%q,\"exitCode\":%d,\"output\":%q}\n",
+		n.Name, exitCodeOf(n), strings.TrimSpace(string(data)),
+	)
+	return os.WriteFile(resolveDest(n, dest), []byte(summary), 0644)
+}
+
+func resolveDest(n *Node, dest string) string {
+	if filepath.IsAbs(dest) {
+		return dest
+	}
+	return filepath.Join(n.Dir, dest)
+}
+
+func exitCodeOf(n *Node) int {
+	if n.Error == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(n.Error, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yohamta/dagu/internal/config"
+)
+
+func newTestNode(dir string) *Node {
+	return &Node{Step: &config.Step{Name: "test", Dir: dir}}
+}
+
+func TestTarExporter_DestInsideDirIsNotSelfArchived(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	n := newTestNode(dir)
+
+	if err := (tarExporter{}).Export(n, "out.tar", nil); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "out.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading tar: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+
+	for _, name := range names {
+		if name == "out.tar" {
+			t.Fatalf("archive contains itself: %v", names)
+		}
+	}
+	if len(names) != 1 || names[0] != "a.txt" {
+		t.Fatalf("expected only a.txt in archive, got %v", names)
+	}
+}
+
+func TestArtifactDirExporter_DestInsideDirDoesNotRecurse(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	n := newTestNode(dir)
+
+	if err := (artifactDirExporter{}).Export(n, "artifacts", nil); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	copied := filepath.Join(dir, "artifacts", "a.txt")
+	if _, err := os.Stat(copied); err != nil {
+		t.Fatalf("expected %s to exist: %v", copied, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "artifacts", "artifacts")); !os.IsNotExist(err) {
+		t.Fatalf("artifacts dir was copied into itself")
+	}
+}
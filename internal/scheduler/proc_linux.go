@@ -0,0 +1,167 @@
+//go:build linux
+
+package scheduler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// processSnapshot is one /proc sample of a process group's resource
+// usage, aggregated across every pid that shares the group's pgid.
+type processSnapshot struct {
+	RSSBytes    uint64
+	CPUSeconds  float64
+	IOReadBytes uint64
+	IOWriteBytes uint64
+}
+
+var clockTicksPerSec = float64(100) // sysconf(_SC_CLK_TCK) is 100 on every Linux dagu targets
+
+// sampleProcessGroup aggregates /proc/<pid>/stat, /status and /io across
+// every process in pgid's group. Missing/exited pids are skipped rather
+// than treated as an error, since a child can exit between the group scan
+// and reading its files.
+func sampleProcessGroup(pgid int) (processSnapshot, error) {
+	pids, err := processesInGroup(pgid)
+	if err != nil {
+		return processSnapshot{}, err
+	}
+
+	var total processSnapshot
+	for _, pid := range pids {
+		if rss, cpu, err := readStat(pid); err == nil {
+			total.RSSBytes += rss
+			total.CPUSeconds += cpu
+		}
+		if r, w, err := readIO(pid); err == nil {
+			total.IOReadBytes += r
+			total.IOWriteBytes += w
+		}
+	}
+	return total, nil
+}
+
+// processesInGroup lists every pid under /proc whose process group id is
+// pgid, by reading field 5 of /proc/<pid>/stat.
+func processesInGroup(pgid int) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		statPgid, err := readPgid(pid)
+		if err != nil {
+			continue
+		}
+		if statPgid == pgid {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+func readPgid(pid int) (int, error) {
+	fields, err := statFields(pid)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) < 5 {
+		return 0, fmt.Errorf("short stat for pid %d", pid)
+	}
+	return strconv.Atoi(fields[4])
+}
+
+// readStat returns a process's RSS (bytes) and cumulative CPU time
+// (seconds, utime+stime) from /proc/<pid>/stat.
+func readStat(pid int) (rssBytes uint64, cpuSeconds float64, err error) {
+	fields, err := statFields(pid)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(fields) < 24 {
+		return 0, 0, fmt.Errorf("short stat for pid %d", pid)
+	}
+	utime, _ := strconv.ParseUint(fields[13], 10, 64)
+	stime, _ := strconv.ParseUint(fields[14], 10, 64)
+	rssPages, _ := strconv.ParseUint(fields[23], 10, 64)
+	cpuSeconds = float64(utime+stime) / clockTicksPerSec
+	rssBytes = rssPages * uint64(os.Getpagesize())
+	return rssBytes, cpuSeconds, nil
+}
+
+// statFields splits /proc/<pid>/stat into whitespace fields, with the
+// parenthesized comm field collapsed to one token first since it can
+// itself contain spaces.
+func statFields(pid int) ([]string, error) {
+	b, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	close := strings.LastIndexByte(s, ')')
+	if close < 0 {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	head := "x x" // pid and collapsed comm, neither of which readStat/readPgid need
+	return strings.Fields(head + s[close+1:]), nil
+}
+
+func readIO(pid int) (readBytes, writeBytes uint64, err error) {
+	f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "io"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), ": ")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "read_bytes":
+			readBytes, _ = strconv.ParseUint(v, 10, 64)
+		case "write_bytes":
+			writeBytes, _ = strconv.ParseUint(v, 10, 64)
+		}
+	}
+	return readBytes, writeBytes, nil
+}
+
+// finalRusageSnapshot reads the wait4-collected rusage for n's own child
+// process once it has exited, for an authoritative peak that can't be
+// missed by the periodic /proc sampler. This must come from
+// cmd.ProcessState.SysUsage() rather than getrusage(RUSAGE_CHILDREN):
+// RUSAGE_CHILDREN accumulates over every child the calling process has
+// ever reaped, so in a scheduler that runs more than one step over its
+// lifetime, a later node's "final" sample would include every earlier
+// node's usage too.
+func finalRusageSnapshot(n *Node) (processSnapshot, error) {
+	n.mu.RLock()
+	cmd := n.cmd
+	n.mu.RUnlock()
+	if cmd == nil || cmd.ProcessState == nil {
+		return processSnapshot{}, fmt.Errorf("node %q: no exited process to sample", n.Name)
+	}
+	ru, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return processSnapshot{}, fmt.Errorf("node %q: rusage unavailable", n.Name)
+	}
+	cpu := float64(ru.Utime.Sec+ru.Stime.Sec) + float64(ru.Utime.Usec+ru.Stime.Usec)/1e6
+	return processSnapshot{
+		RSSBytes:   uint64(ru.Maxrss) * 1024, // ru_maxrss is in KB on Linux
+		CPUSeconds: cpu,
+	}, nil
+}
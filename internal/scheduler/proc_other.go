@@ -0,0 +1,23 @@
+//go:build !linux
+
+package scheduler
+
+import "fmt"
+
+// Resource metering samples /proc, which only exists on Linux. On other
+// platforms sampling is a no-op rather than a hard failure, so `resources:`
+// limits are simply never enforced there.
+type processSnapshot struct {
+	RSSBytes     uint64
+	CPUSeconds   float64
+	IOReadBytes  uint64
+	IOWriteBytes uint64
+}
+
+func sampleProcessGroup(pgid int) (processSnapshot, error) {
+	return processSnapshot{}, fmt.Errorf("resource metering is only supported on linux")
+}
+
+func finalRusageSnapshot(n *Node) (processSnapshot, error) {
+	return processSnapshot{}, fmt.Errorf("resource metering is only supported on linux")
+}
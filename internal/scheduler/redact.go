@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"bytes"
+	"io"
+)
+
+const redactedPlaceholder = "***"
+
+// redactingWriter wraps an io.Writer and replaces any occurrence of a set
+// of secret values with a placeholder before the bytes reach the
+// underlying writer. Secret values must never reach n.Log/n.Stdout, even
+// when a match straddles two separate Write calls (a command killed
+// mid-stream can write at any buffer boundary), so unflushed bytes that
+// could still be the prefix of a match are held back until either more
+// data arrives or Close is called.
+type redactingWriter struct {
+	w       io.Writer
+	secrets [][]byte
+	maxLen  int
+	pending []byte
+}
+
+// newRedactingWriter wraps w, redacting every value in secrets. Empty
+// values are ignored so an unset secret doesn't turn into a no-op
+// "redact everything" writer.
+func newRedactingWriter(w io.Writer, secrets []string) *redactingWriter {
+	rw := &redactingWriter{w: w}
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		rw.secrets = append(rw.secrets, []byte(s))
+		if len(s) > rw.maxLen {
+			rw.maxLen = len(s)
+		}
+	}
+	return rw
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	if len(rw.secrets) == 0 {
+		return rw.w.Write(p)
+	}
+
+	n := len(p)
+	buf := append(rw.pending, p...)
+	rw.pending = nil
+
+	// Redact every fully-contained match across the *whole* buffer first,
+	// not just the portion we're about to flush. Any match that starts
+	// earlier than (maxLen-1) bytes from the end has its entire span
+	// already present in buf, so it's found here regardless of where the
+	// eventual flush/hold-back split falls; scanning only the flushed
+	// prefix (as a naive truncate-then-replace would) misses matches that
+	// start inside it but extend past the cut point.
+	redacted := redact(buf, rw.secrets)
+
+	// Keep back the last (maxLen-1) bytes of the redacted result: they
+	// could still be the unfinished prefix of a secret that continues in
+	// the next Write call.
+	holdBack := rw.maxLen - 1
+	flushLen := len(redacted)
+	if holdBack > 0 && flushLen > holdBack {
+		flushLen -= holdBack
+	} else if holdBack > 0 {
+		flushLen = 0
+	}
+
+	toWrite := redacted[:flushLen]
+	rw.pending = append(rw.pending, redacted[flushLen:]...)
+
+	if _, err := rw.w.Write(toWrite); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close flushes any bytes held back for boundary safety, redacting them
+// as a final chunk. It must be called once the writer producing output
+// has exited so a trailing partial secret isn't silently dropped on the
+// floor or, worse, left un-redacted.
+func (rw *redactingWriter) Close() error {
+	if len(rw.pending) == 0 {
+		return nil
+	}
+	toWrite := redact(rw.pending, rw.secrets)
+	rw.pending = nil
+	_, err := rw.w.Write(toWrite)
+	return err
+}
+
+func redact(p []byte, secrets [][]byte) []byte {
+	for _, s := range secrets {
+		p = bytes.ReplaceAll(p, s, []byte(redactedPlaceholder))
+	}
+	return p
+}
@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRedactingWriter_MatchWithinSingleWrite(t *testing.T) {
+	var out bytes.Buffer
+	rw := newRedactingWriter(&out, []string{"SECRET1234"})
+
+	// The secret starts well before the hold-back window at the end of
+	// the buffer, so it must be found and replaced in a single Write call.
+	if _, err := rw.Write([]byte("0123456789012345SECRET123467890")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if bytes.Contains(out.Bytes(), []byte("SECRET1234")) {
+		t.Fatalf("secret leaked into output: %q", out.String())
+	}
+	if !bytes.Contains(out.Bytes(), []byte("***")) {
+		t.Fatalf("expected a redaction placeholder, got %q", out.String())
+	}
+}
+
+func TestRedactingWriter_MatchAcrossWrites(t *testing.T) {
+	var out bytes.Buffer
+	rw := newRedactingWriter(&out, []string{"SECRET1234"})
+
+	if _, err := rw.Write([]byte("prefix SEC")); err != nil {
+		t.Fatalf("Write 1: %v", err)
+	}
+	if _, err := rw.Write([]byte("RET1234 suffix")); err != nil {
+		t.Fatalf("Write 2: %v", err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if bytes.Contains(out.Bytes(), []byte("SECRET1234")) {
+		t.Fatalf("secret leaked into output: %q", out.String())
+	}
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Runtime executes a single Node's command and reports its outcome. It is
+// the seam between the scheduler's bookkeeping (logs, output capture,
+// retries, signalling) and the actual place a step's command runs.
+type Runtime interface {
+	// Start launches the command described by n and blocks until it exits,
+	// returning the error from running it (nil on a zero exit code).
+	Start(ctx context.Context, n *Node, stdout, stderr io.Writer) error
+	// Signal forwards sig to the running process started by Start.
+	Signal(n *Node, sig os.Signal) error
+}
+
+// newRuntime picks the Runtime implementation for a node: a `runsOn:`
+// selector dispatches to a remote agent, a `container:` config runs the
+// step in Docker, and otherwise it runs directly on the scheduler host as
+// it always has.
+func newRuntime(n *Node) Runtime {
+	if n.RunsOn != "" {
+		return &RemoteRuntime{}
+	}
+	if n.Container != nil {
+		return &DockerRuntime{}
+	}
+	return &LocalRuntime{}
+}
+
+// LocalRuntime runs a step's command directly on the scheduler host via
+// exec.CommandContext, in its own process group so signals can be sent to
+// the whole tree. This is the runtime dagu has always used.
+type LocalRuntime struct{}
+
+func (r *LocalRuntime) Start(ctx context.Context, n *Node, stdout, stderr io.Writer) error {
+	args := n.Args
+	if n.scriptFile != nil {
+		args = append(append([]string{}, n.Args...), n.scriptFile.Name())
+	}
+	cmd := exec.CommandContext(ctx, n.Command, args...)
+	cmd.Dir = n.Dir
+	cmd.Env = append(cmd.Env, n.Variables...)
+	cmd.Env = append(cmd.Env, n.secretEnv...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	n.mu.Lock()
+	n.cmd = cmd
+	n.mu.Unlock()
+
+	return cmd.Run()
+}
+
+func (r *LocalRuntime) Signal(n *Node, sig os.Signal) error {
+	if n.cmd == nil || n.cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-n.cmd.Process.Pid, sig.(syscall.Signal))
+}
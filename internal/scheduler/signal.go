@@ -0,0 +1,23 @@
+package scheduler
+
+import "os"
+
+// signalName maps an os.Signal to the name external tools expect on their
+// command line (`docker kill --signal`, the agent's SignalRequest, ...).
+// signal.String() returns human phrases like "terminated"/"killed", which
+// none of those accept - they want "SIGTERM"/"SIGKILL".
+func signalName(sig os.Signal) string {
+	if s, ok := sig.(interface{ String() string }); ok {
+		switch s.String() {
+		case "terminated":
+			return "SIGTERM"
+		case "killed":
+			return "SIGKILL"
+		case "interrupt":
+			return "SIGINT"
+		case "hangup":
+			return "SIGHUP"
+		}
+	}
+	return "SIGTERM"
+}
@@ -0,0 +1,19 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// EnvProvider resolves a secret from an environment variable already set
+// on the scheduler process, e.g. `env:DB_PASSWORD`.
+type EnvProvider struct{}
+
+func (EnvProvider) Resolve(_ context.Context, ref string) (string, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %q is not set", ref)
+	}
+	return v, nil
+}
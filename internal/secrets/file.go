@@ -0,0 +1,20 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// FileProvider resolves a secret from the trimmed contents of a file on
+// disk, e.g. `file:/run/secrets/db_password`. This is the common shape for
+// Docker/Kubernetes-mounted secrets.
+type FileProvider struct{}
+
+func (FileProvider) Resolve(_ context.Context, ref string) (string, error) {
+	b, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
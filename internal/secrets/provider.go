@@ -0,0 +1,67 @@
+// Package secrets resolves references to secret values at Node setup time
+// so they can be injected into a step's environment without ever being
+// written to a DAG file in plaintext.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Provider resolves a single secret reference to its value.
+type Provider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// providers is the registry of providers keyed by the scheme prefix used
+// in a `secrets:` entry, e.g. `vault:secret/data/db#password`.
+var providers = map[string]Provider{
+	"env":   EnvProvider{},
+	"file":  FileProvider{},
+	"vault": VaultProvider{},
+	"aws":   AWSProvider{},
+	"gcp":   GCPProvider{},
+}
+
+// Register adds or overrides the provider used for a given scheme.
+func Register(scheme string, p Provider) {
+	providers[scheme] = p
+}
+
+// Resolve parses a `scheme:ref` secret reference and resolves it via the
+// matching registered Provider.
+func Resolve(ctx context.Context, reference string) (string, error) {
+	scheme, ref, ok := strings.Cut(reference, ":")
+	if !ok {
+		return "", fmt.Errorf("secret reference %q is missing a scheme (e.g. env:FOO)", reference)
+	}
+	p, ok := providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider %q", scheme)
+	}
+	v, err := p.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %q: %w", reference, err)
+	}
+	return v, nil
+}
+
+// ResolveEnv parses a list of `NAME=scheme:ref` entries (the shape used by
+// a step's `secrets:` config) into environment-variable assignments ready
+// to append to cmd.Env.
+func ResolveEnv(ctx context.Context, entries []string) (env []string, values []string, err error) {
+	for _, e := range entries {
+		name, reference, ok := strings.Cut(e, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid secrets entry %q, want NAME=scheme:ref", e)
+		}
+		v, err := Resolve(ctx, reference)
+		if err != nil {
+			return nil, nil, err
+		}
+		env = append(env, name+"="+v)
+		values = append(values, v)
+	}
+	return env, values, nil
+}
@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// VaultProvider resolves a secret from HashiCorp Vault's KV v2 API. A
+// reference has the form `path#field`, e.g.
+// `vault:secret/data/db#password`. Addr and token are taken from the
+// standard VAULT_ADDR / VAULT_TOKEN environment variables so the scheduler
+// needs no extra config to use whatever Vault login the operator already
+// has set up.
+type VaultProvider struct{}
+
+func (VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok {
+		return "", fmt.Errorf("vault reference %q must be path#field", ref)
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set to resolve vault secrets")
+	}
+
+	url := strings.TrimRight(addr, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+
+	v, ok := body.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found at vault path %q", field, path)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q at vault path %q is not a string", field, path)
+	}
+	return s, nil
+}